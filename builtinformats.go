@@ -0,0 +1,203 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// The built-in formats below are migrated to the FormatChecker signature
+// (RegisterFormat) rather than the old bool-returning one: each explains
+// why a value failed instead of just rejecting it.
+
+func dateTimeFormat(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, s); err != nil {
+		return fmt.Errorf("invalid date-time %q: %v", s, err)
+	}
+	return nil
+}
+
+func emailFormat(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	if _, err := mail.ParseAddress(s); err != nil {
+		return fmt.Errorf("invalid email %q: %v", s, err)
+	}
+	return nil
+}
+
+func hostnameFormat(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	if len(s) > 255 {
+		return fmt.Errorf("invalid hostname %q: longer than 255 characters", s)
+	}
+	for _, label := range splitHostname(s) {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("invalid hostname %q: label %q must be 1-63 characters", s, label)
+		}
+	}
+	return nil
+}
+
+func splitHostname(s string) []string {
+	var labels []string
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i == len(s) || s[i] == '.' {
+			labels = append(labels, s[start:i])
+			start = i + 1
+		}
+	}
+	return labels
+}
+
+func ipv4Format(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() == nil {
+		return fmt.Errorf("invalid ipv4 address %q", s)
+	}
+	return nil
+}
+
+func ipv6Format(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() != nil {
+		return fmt.Errorf("invalid ipv6 address %q", s)
+	}
+	return nil
+}
+
+func uriFormat(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid uri %q: %v", s, err)
+	}
+	if !u.IsAbs() {
+		return fmt.Errorf("invalid uri %q: not absolute", s)
+	}
+	return nil
+}
+
+func regexFormat(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	if _, err := regexp.Compile(s); err != nil {
+		return fmt.Errorf("invalid regex %q: %v", s, err)
+	}
+	return nil
+}
+
+// durationFormat accepts a Go duration string, e.g. "5s". It rejects with
+// a message explaining *why*, e.g. `invalid duration "5xy": time: unknown
+// unit "xy" in duration "5xy"` -- the non-string FormatChecker signature
+// this request adds is what makes that message (instead of a bare "not a
+// valid duration") possible.
+func durationFormat(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil // format applies only to strings; non-strings are valid.
+	}
+	if _, err := time.ParseDuration(s); err != nil {
+		return fmt.Errorf("invalid duration %q: %v", s, err)
+	}
+	return nil
+}
+
+// portsFormat accepts a single port number or an array of port numbers,
+// which a bool-returning, string-only FormatChecker could not express.
+func portsFormat(v interface{}) error {
+	switch val := v.(type) {
+	case float64:
+		return checkPort(val)
+	case []interface{}:
+		for _, item := range val {
+			n, ok := item.(float64)
+			if !ok {
+				return fmt.Errorf("invalid ports: %v is not a number", item)
+			}
+			if err := checkPort(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return nil // non-numeric, non-array instance; format does not apply.
+	}
+}
+
+func checkPort(n float64) error {
+	if n != float64(int(n)) || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %v: must be an integer in [0, 65535]", n)
+	}
+	return nil
+}
+
+func base64ContentEncoding(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+		return fmt.Errorf("invalid base64: %v", err)
+	}
+	return nil
+}
+
+func jsonContentMediaType(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	var discard interface{}
+	if err := json.Unmarshal([]byte(s), &discard); err != nil {
+		return fmt.Errorf("invalid json: %v", err)
+	}
+	return nil
+}
+
+func init() {
+	builtinFormats["date-time"] = dateTimeFormat
+	builtinFormats["email"] = emailFormat
+	builtinFormats["hostname"] = hostnameFormat
+	builtinFormats["ipv4"] = ipv4Format
+	builtinFormats["ipv6"] = ipv6Format
+	builtinFormats["uri"] = uriFormat
+	builtinFormats["regex"] = regexFormat
+	builtinFormats["duration"] = durationFormat
+	builtinFormats["ports"] = portsFormat
+
+	builtinContentEncodings["base64"] = base64ContentEncoding
+	builtinContentMediaTypes["application/json"] = jsonContentMediaType
+}
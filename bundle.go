@@ -0,0 +1,269 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CircularRefPolicy controls how Bundle handles a $ref that points back to
+// a schema already being emitted.
+type CircularRefPolicy int
+
+const (
+	// CircularRefLocalPointer rewrites a circular $ref to a local pointer
+	// into the bundled document's $defs, same as any other cross-document
+	// ref. This is the default.
+	CircularRefLocalPointer CircularRefPolicy = iota
+
+	// CircularRefError makes Bundle fail instead of silently closing the
+	// cycle with a local pointer.
+	CircularRefError
+)
+
+// BundleOptions controls Schema.Bundle and Compiler.Bundle.
+type BundleOptions struct {
+	// Inline, when true, expands every $ref in place instead of
+	// collecting externally-defined schemas into a $defs section.
+	// Recursive refs are still closed with a local pointer (or rejected,
+	// per CircularRef) since expanding them in place would never
+	// terminate.
+	Inline bool
+
+	// KeepDescriptions keeps $comment/title/description on the bundled
+	// copies of referenced schemas. When false (the default) they are
+	// stripped to keep the bundle small.
+	KeepDescriptions bool
+
+	// InlineCrossID controls whether Inline also expands a $ref that
+	// crosses a $id boundary (i.e. points into a schema that declares its
+	// own $id, meaning it was originally a separate document). When false
+	// (the default), such refs are always bundled as a local "$ref" into
+	// $defs, even when Inline is true; set it to true to expand them in
+	// place like any same-document ref.
+	InlineCrossID bool
+
+	// CircularRef selects how cycles are handled. Defaults to
+	// CircularRefLocalPointer.
+	CircularRef CircularRefPolicy
+}
+
+// Bundle walks sch and every schema it (transitively) references via
+// $ref, and returns a single self-contained JSON Schema document: external
+// refs are inlined into "$defs" with pointers rewritten to stay local,
+// preserving $id and $anchor. Cycles always become local pointers (or an
+// error, per opts.CircularRef.Policy) rather than being expanded, even
+// when opts.Inline is set.
+func (s *Schema) Bundle(opts BundleOptions) ([]byte, error) {
+	b := &bundler{
+		opts:   opts,
+		rootID: s.ID,
+		defs:   make(map[*Schema]string),
+		onPath: make(map[*Schema]bool),
+	}
+	doc, err := b.encode(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	// b.order can grow while its own entries are being encoded: encoding
+	// one $defs entry may discover further external refs via localRef,
+	// appending to b.order. Index by position, not range, so those newly
+	// discovered defs are emitted too instead of being left as dangling
+	// "$ref"s.
+	if len(b.order) > 0 {
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("jsonschema: cannot bundle non-object root schema with external refs")
+		}
+		defs := make(map[string]interface{}, len(b.order))
+		for i := 0; i < len(b.order); i++ {
+			sch := b.order[i]
+			name := b.defs[sch]
+			encoded, err := b.encode(sch, map[*Schema]bool{sch: true})
+			if err != nil {
+				return nil, err
+			}
+			defs[name] = encoded
+		}
+		m["$defs"] = defs
+		doc = m
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// Bundle compiles url and bundles the result with default options. It is a
+// convenience for the common case of producing a single portable schema
+// file straight from a Compiler that has already done all the ref
+// resolution work needed to load url and everything it references.
+func (c *Compiler) Bundle(url string) ([]byte, error) {
+	sch, err := c.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: compiling %s: %w", url, err)
+	}
+	return sch.Bundle(BundleOptions{})
+}
+
+type bundler struct {
+	opts   BundleOptions
+	rootID string             // $id of the schema Bundle was called on
+	defs   map[*Schema]string // schema -> name under $defs
+	order  []*Schema          // definition order, for deterministic output
+	onPath map[*Schema]bool   // schemas on the current encode() call stack
+}
+
+// crossesID reports whether target is a different document than the one
+// Bundle was called on, i.e. it declares its own $id distinct from
+// b.rootID. Such a target is what BundleOptions.InlineCrossID gates.
+func (b *bundler) crossesID(target *Schema) bool {
+	return target.ID != "" && target.ID != b.rootID
+}
+
+// encode renders sch as a JSON value. visiting tracks the schemas on the
+// current recursion path so a cycle back onto it is detected and closed
+// with a local pointer (or rejected) instead of recursing forever.
+func (b *bundler) encode(sch *Schema, visiting map[*Schema]bool) (interface{}, error) {
+	if visiting == nil {
+		visiting = make(map[*Schema]bool)
+	}
+
+	if sch.Ref != nil {
+		target := sch.Ref
+		if visiting[target] {
+			return b.localRef(target)
+		}
+		if !b.opts.Inline {
+			return b.localRef(target)
+		}
+		if b.crossesID(target) && !b.opts.InlineCrossID {
+			return b.localRef(target)
+		}
+		next := make(map[*Schema]bool, len(visiting)+1)
+		for k := range visiting {
+			next[k] = true
+		}
+		next[target] = true
+		return b.encode(target, next)
+	}
+
+	if sch.Boolean != nil {
+		return *sch.Boolean, nil
+	}
+
+	m := make(map[string]interface{})
+	if b.opts.KeepDescriptions {
+		if sch.Title != "" {
+			m["title"] = sch.Title
+		}
+		if sch.Description != "" {
+			m["description"] = sch.Description
+		}
+		if sch.Comment != "" {
+			m["$comment"] = sch.Comment
+		}
+	}
+	if sch.Anchor != "" {
+		m["$anchor"] = sch.Anchor
+	}
+
+	next := make(map[*Schema]bool, len(visiting)+1)
+	for k := range visiting {
+		next[k] = true
+	}
+	next[sch] = true
+
+	for key, sub := range sch.Properties {
+		if m["properties"] == nil {
+			m["properties"] = make(map[string]interface{})
+		}
+		encoded, err := b.encodeRef(sub, next)
+		if err != nil {
+			return nil, err
+		}
+		m["properties"].(map[string]interface{})[key] = encoded
+	}
+	if sch.Items != nil {
+		encoded, err := b.encodeRef(sch.Items, next)
+		if err != nil {
+			return nil, err
+		}
+		m["items"] = encoded
+	}
+	for name, subs := range map[string][]*Schema{"allOf": sch.AllOf, "anyOf": sch.AnyOf, "oneOf": sch.OneOf} {
+		if len(subs) == 0 {
+			continue
+		}
+		var arr []interface{}
+		for _, sub := range subs {
+			encoded, err := b.encodeRef(sub, next)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, encoded)
+		}
+		m[name] = arr
+	}
+	if len(sch.Types) > 0 {
+		if len(sch.Types) == 1 {
+			m["type"] = sch.Types[0]
+		} else {
+			m["type"] = sch.Types
+		}
+	}
+	if len(sch.Required) > 0 {
+		m["required"] = sch.Required
+	}
+	if len(sch.Enum) > 0 {
+		m["enum"] = sch.Enum
+	}
+
+	return m, nil
+}
+
+// encodeRef either inlines sub (opts.Inline, non-cyclic) or registers it
+// as a named $defs entry and returns a local "$ref" pointer to it,
+// depending on whether sub came from a different document (different
+// $id) than the one currently being walked.
+func (b *bundler) encodeRef(sub *Schema, visiting map[*Schema]bool) (interface{}, error) {
+	if visiting[sub] {
+		switch b.opts.CircularRef {
+		case CircularRefError:
+			return nil, fmt.Errorf("jsonschema: circular reference at %s", sub.Location)
+		default:
+			return b.localRef(sub)
+		}
+	}
+	return b.encode(sub, visiting)
+}
+
+func (b *bundler) localRef(sch *Schema) (interface{}, error) {
+	name, ok := b.defs[sch]
+	if !ok {
+		name = defName(sch, len(b.order))
+		b.defs[sch] = name
+		b.order = append(b.order, sch)
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + name}, nil
+}
+
+func defName(sch *Schema, index int) string {
+	if sch.Anchor != "" {
+		return sch.Anchor
+	}
+	if sch.ID != "" {
+		return sanitizeDefName(sch.ID)
+	}
+	return fmt.Sprintf("def%d", index)
+}
+
+func sanitizeDefName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestBundleClosesCycle ensures a schema that (transitively) $refs itself
+// is bundled as a local pointer instead of recursing forever.
+func TestBundleClosesCycle(t *testing.T) {
+	node := &Schema{Location: "https://example.com/node.json"}
+	node.Properties = map[string]*Schema{
+		"next": {Location: "https://example.com/node.json#/properties/next", Ref: node},
+	}
+
+	out, err := node.Bundle(BundleOptions{Inline: true})
+	if err != nil {
+		t.Fatalf("Bundle: %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unmarshal bundle output: %v", err)
+	}
+	if _, ok := doc["$defs"]; !ok {
+		t.Fatalf("expected cycle to be closed via $defs, got: %s", out)
+	}
+	if !strings.Contains(string(out), `"$ref"`) {
+		t.Fatalf("expected a local $ref closing the cycle, got: %s", out)
+	}
+}
+
+// TestBundleGrowingDefsAreEmitted ensures a def discovered while encoding
+// an earlier def (not reachable directly from the root) still ends up in
+// the bundled $defs, rather than being left as a dangling $ref.
+func TestBundleGrowingDefsAreEmitted(t *testing.T) {
+	leaf := &Schema{Location: "https://example.com/leaf.json", ID: "https://example.com/leaf.json"}
+	mid := &Schema{Location: "https://example.com/mid.json", ID: "https://example.com/mid.json"}
+	mid.Properties = map[string]*Schema{
+		"leaf": {Location: "https://example.com/mid.json#/properties/leaf", Ref: leaf},
+	}
+	root := &Schema{Location: "https://example.com/root.json"}
+	root.Properties = map[string]*Schema{
+		"mid": {Location: "https://example.com/root.json#/properties/mid", Ref: mid},
+	}
+
+	out, err := root.Bundle(BundleOptions{})
+	if err != nil {
+		t.Fatalf("Bundle: %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unmarshal bundle output: %v", err)
+	}
+	defs, _ := doc["$defs"].(map[string]interface{})
+	if len(defs) != 2 {
+		t.Fatalf("expected both mid and leaf in $defs, got: %s", out)
+	}
+}
+
+// TestBundleInlineCrossID ensures InlineCrossID gates whether Inline
+// expands a ref that crosses into a schema with its own $id, rather than
+// always inlining (or never inlining) regardless of the option.
+func TestBundleInlineCrossID(t *testing.T) {
+	other := &Schema{Location: "https://example.com/other.json", ID: "https://example.com/other.json"}
+	other.Types = []string{"string"}
+	root := &Schema{Location: "https://example.com/root.json"}
+	root.Properties = map[string]*Schema{
+		"other": {Location: "https://example.com/root.json#/properties/other", Ref: other},
+	}
+
+	out, err := root.Bundle(BundleOptions{Inline: true})
+	if err != nil {
+		t.Fatalf("Bundle: %v", err)
+	}
+	if !strings.Contains(string(out), `"$ref"`) {
+		t.Fatalf("expected cross-$id ref to stay a local $ref by default, got: %s", out)
+	}
+
+	out, err = root.Bundle(BundleOptions{Inline: true, InlineCrossID: true})
+	if err != nil {
+		t.Fatalf("Bundle: %v", err)
+	}
+	if strings.Contains(string(out), `"$ref"`) {
+		t.Fatalf("expected cross-$id ref to be inlined with InlineCrossID, got: %s", out)
+	}
+}
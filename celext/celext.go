@@ -0,0 +1,235 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package celext provides a ready-to-use jsonschema.ExtCompiler/ExtSchema
+// pair that implements the `x-kubernetes-validations` (and its shorter
+// alias `x-validations`) keyword using google/cel-go, the same approach
+// Kubernetes CRDs and Crossplane compositions use for in-schema validation
+// rules.
+package celext
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types/ref"
+
+	"github.com/afbjorklund/jsonschema"
+)
+
+// Keywords recognized by this extension. x-kubernetes-validations is the
+// name Kubernetes uses; x-validations is accepted as a shorter alias.
+const (
+	KeywordKubernetes = "x-kubernetes-validations"
+	Keyword           = "x-validations"
+)
+
+// Rule is a single CEL validation rule, matching the shape Kubernetes uses
+// for CRD `x-kubernetes-validations`.
+type Rule struct {
+	Rule              string `json:"rule"`
+	Message           string `json:"message,omitempty"`
+	MessageExpression string `json:"messageExpression,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+	FieldPath         string `json:"fieldPath,omitempty"`
+}
+
+type compiledRule struct {
+	rule    Rule
+	prg     cel.Program
+	msgPrg  cel.Program // non-nil when MessageExpression is set
+	keyword string      // keyword this rule was compiled from, for error locations
+	index   int
+}
+
+type schemaExt struct {
+	rules []compiledRule
+}
+
+// Compiler implements jsonschema.ExtCompiler for the x-validations /
+// x-kubernetes-validations keywords.
+type compiler struct{}
+
+// New returns an ExtCompiler that understands x-validations and
+// x-kubernetes-validations. Register it with:
+//
+//	c := jsonschema.NewCompiler()
+//	c.RegisterExtension("celext", celext.Meta, celext.New())
+func New() jsonschema.ExtCompiler {
+	return compiler{}
+}
+
+// Meta is the metaschema that allows (but does not require) the
+// x-validations/x-kubernetes-validations keywords. It is intentionally
+// permissive since the detailed shape of each rule is checked while
+// compiling the CEL expressions, where better error messages can be given.
+var Meta = jsonschema.MustCompileString("celext.json", `{
+	"properties": {
+		"x-validations": {
+			"type": "array",
+			"items": { "type": "object", "required": ["rule"] }
+		},
+		"x-kubernetes-validations": {
+			"type": "array",
+			"items": { "type": "object", "required": ["rule"] }
+		}
+	}
+}`)
+
+// ruleEntry pairs a parsed Rule with the keyword (Keyword or
+// KeywordKubernetes) it was read from, so that pairing survives into the
+// compiledRule even though both keywords are collected in one pass.
+type ruleEntry struct {
+	rule    Rule
+	keyword string
+}
+
+func (compiler) Compile(ctx jsonschema.CompilerContext, m map[string]interface{}) (jsonschema.ExtSchema, error) {
+	var entries []ruleEntry
+	for _, keyword := range []string{Keyword, KeywordKubernetes} {
+		v, ok := m[keyword]
+		if !ok {
+			continue
+		}
+		raw, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("celext: %s must be an array", keyword)
+		}
+		for _, item := range raw {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("celext: %s items must be objects", keyword)
+			}
+			entries = append(entries, ruleEntry{rule: ruleFromMap(obj), keyword: keyword})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	env, err := newEnv(m)
+	if err != nil {
+		return nil, fmt.Errorf("celext: building cel environment: %w", err)
+	}
+
+	ext := &schemaExt{}
+	for i, e := range entries {
+		rule := e.rule
+		ast, iss := env.Compile(rule.Rule)
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("celext: compiling rule %q: %w", rule.Rule, iss.Err())
+		}
+		prg, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("celext: building program for rule %q: %w", rule.Rule, err)
+		}
+		cr := compiledRule{rule: rule, prg: prg, keyword: e.keyword, index: i}
+		if rule.MessageExpression != "" {
+			msgAst, iss := env.Compile(rule.MessageExpression)
+			if iss.Err() != nil {
+				return nil, fmt.Errorf("celext: compiling messageExpression %q: %w", rule.MessageExpression, iss.Err())
+			}
+			msgPrg, err := env.Program(msgAst)
+			if err != nil {
+				return nil, fmt.Errorf("celext: building program for messageExpression %q: %w", rule.MessageExpression, err)
+			}
+			cr.msgPrg = msgPrg
+		}
+		ext.rules = append(ext.rules, cr)
+	}
+	return ext, nil
+}
+
+func ruleFromMap(m map[string]interface{}) Rule {
+	str := func(k string) string {
+		s, _ := m[k].(string)
+		return s
+	}
+	return Rule{
+		Rule:              str("rule"),
+		Message:           str("message"),
+		MessageExpression: str("messageExpression"),
+		Reason:            str("reason"),
+		FieldPath:         str("fieldPath"),
+	}
+}
+
+// newEnv builds a CEL environment with `self` typed from the surrounding
+// schema, falling back to cel.DynType when the schema's type is unknown or
+// mixed so authoring still works, it just loses compile-time checking.
+func newEnv(m map[string]interface{}) (*cel.Env, error) {
+	return cel.NewEnv(cel.Variable("self", selfType(m)))
+}
+
+func selfType(m map[string]interface{}) *cel.Type {
+	t, _ := m["type"].(string)
+	switch t {
+	case "object":
+		return cel.MapType(cel.StringType, cel.DynType)
+	case "array":
+		return cel.ListType(cel.DynType)
+	case "string":
+		return cel.StringType
+	case "number":
+		return cel.DoubleType
+	case "integer":
+		return cel.IntType
+	case "boolean":
+		return cel.BoolType
+	default:
+		return cel.DynType
+	}
+}
+
+func (e *schemaExt) Validate(ctx jsonschema.ValidationContext, v interface{}) error {
+	for _, cr := range e.rules {
+		out, _, err := cr.prg.Eval(map[string]interface{}{"self": v})
+		if err != nil {
+			return cr.error(ctx, "rule %q: %v", cr.rule.Rule, err)
+		}
+		ok, isBool := out.Value().(bool)
+		if !isBool {
+			return cr.error(ctx, "rule %q did not evaluate to a bool", cr.rule.Rule)
+		}
+		if ok {
+			continue
+		}
+		if cr.msgPrg != nil {
+			if msg, _, err := cr.msgPrg.Eval(map[string]interface{}{"self": v}); err == nil {
+				if s, ok := messageString(msg); ok {
+					return cr.error(ctx, "%s", s)
+				}
+			}
+		}
+		if cr.rule.Message != "" {
+			return cr.error(ctx, "%s", cr.rule.Message)
+		}
+		return cr.error(ctx, "failed rule: %s", cr.rule.Rule)
+	}
+	return nil
+}
+
+// error builds the *jsonschema.ValidationError for a failed rule,
+// prefixing the rule's reason (mirroring Kubernetes' FieldValueInvalid /
+// FieldValueRequired / FieldValueForbidden / FieldValueDuplicate reason
+// codes) when set, and redirecting InstanceLocation to fieldPath when the
+// rule names one -- Kubernetes CRD validation rules use fieldPath to point
+// at the part of the instance the rule is actually about, which is often
+// not the schema location the rule itself is attached to.
+func (cr compiledRule) error(ctx jsonschema.ValidationContext, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if cr.rule.Reason != "" {
+		msg = fmt.Sprintf("%s: %s", cr.rule.Reason, msg)
+	}
+	verr := ctx.Error(cr.keyword, "%s", msg)
+	if cr.rule.FieldPath != "" {
+		verr.InstanceLocation = cr.rule.FieldPath
+	}
+	return verr
+}
+
+func messageString(v ref.Val) (string, bool) {
+	s, ok := v.Value().(string)
+	return s, ok
+}
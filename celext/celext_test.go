@@ -0,0 +1,86 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package celext
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/afbjorklund/jsonschema"
+)
+
+func newTestCompiler(docs map[string]string) *jsonschema.Compiler {
+	c := jsonschema.NewCompiler()
+	c.SetRefLoader(jsonschema.RefLoaderFunc(func(ref string) (json.RawMessage, error) {
+		return json.RawMessage(docs[ref]), nil
+	}))
+	c.RegisterExtension("celext", Meta, New())
+	return c
+}
+
+// TestKeywordLocationMatchesOwnKeyword ensures a rule's reported keyword
+// location reflects the keyword (x-validations vs x-kubernetes-validations)
+// it was actually declared under, not whichever keyword happened to be
+// compiled last.
+func TestKeywordLocationMatchesOwnKeyword(t *testing.T) {
+	doc := `{
+		"$id": "https://example.com/schema.json",
+		"type": "object",
+		"x-validations": [{"rule": "false", "message": "from x-validations"}],
+		"x-kubernetes-validations": [{"rule": "true"}]
+	}`
+	c := newTestCompiler(map[string]string{"https://example.com/schema.json": doc})
+	sch, err := c.Compile("https://example.com/schema.json")
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	err = sch.Validate(map[string]interface{}{})
+	if err == nil {
+		t.Fatalf("expected the x-validations rule to fail")
+	}
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("expected *jsonschema.ValidationError, got %T", err)
+	}
+	if !strings.Contains(ve.KeywordLocation, Keyword) {
+		t.Fatalf("expected keyword location to reference %q, got %q", Keyword, ve.KeywordLocation)
+	}
+	if strings.Contains(ve.KeywordLocation, KeywordKubernetes) {
+		t.Fatalf("keyword location %q wrongly attributes the failing rule to %q", ve.KeywordLocation, KeywordKubernetes)
+	}
+}
+
+// TestFieldPathRedirectsInstanceLocation ensures a rule's fieldPath, when
+// set, is used as the reported InstanceLocation instead of the schema's
+// own location -- mirroring Kubernetes' fieldPath semantics.
+func TestFieldPathRedirectsInstanceLocation(t *testing.T) {
+	doc := `{
+		"$id": "https://example.com/schema.json",
+		"type": "object",
+		"x-validations": [{"rule": "false", "reason": "FieldValueInvalid", "fieldPath": ".spec.name"}]
+	}`
+	c := newTestCompiler(map[string]string{"https://example.com/schema.json": doc})
+	sch, err := c.Compile("https://example.com/schema.json")
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	err = sch.Validate(map[string]interface{}{})
+	if err == nil {
+		t.Fatalf("expected the rule to fail")
+	}
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("expected *jsonschema.ValidationError, got %T", err)
+	}
+	if ve.InstanceLocation != ".spec.name" {
+		t.Fatalf("expected InstanceLocation %q, got %q", ".spec.name", ve.InstanceLocation)
+	}
+	if !strings.Contains(ve.Message, "FieldValueInvalid") {
+		t.Fatalf("expected message to include the rule's reason, got %q", ve.Message)
+	}
+}
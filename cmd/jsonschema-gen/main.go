@@ -0,0 +1,59 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command jsonschema-gen generates a Go source file containing type
+// declarations for a JSON Schema document.
+//
+// Usage:
+//
+//	jsonschema-gen -pkg mypkg -type Config schema.json > config_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/afbjorklund/jsonschema"
+	"github.com/afbjorklund/jsonschema/codegen"
+)
+
+func main() {
+	pkg := flag.String("pkg", "main", "package name for the generated file")
+	typeName := flag.String("type", "Schema", "Go type name for the root schema")
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: jsonschema-gen [flags] schema.json")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *pkg, *typeName, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "jsonschema-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath, pkg, typeName, out string) error {
+	c := jsonschema.NewCompiler()
+	sch, err := c.Compile(schemaPath)
+	if err != nil {
+		return fmt.Errorf("compiling %s: %w", schemaPath, err)
+	}
+
+	g := codegen.NewGenerator(pkg)
+	g.Add(typeName, sch)
+	src, err := g.Generate()
+	if err != nil {
+		return fmt.Errorf("generating code: %w", err)
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
@@ -0,0 +1,504 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package codegen generates idiomatic Go type declarations from a compiled
+// jsonschema.Schema tree, so that users don't have to hand-write structs
+// that merely shadow their schemas.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"github.com/afbjorklund/jsonschema"
+)
+
+// TypeMapper lets callers override how a *jsonschema.Schema is rendered as
+// a Go type, which is how custom keywords registered through
+// Compiler.RegisterExtension can participate in code generation.
+//
+// Map returns the Go type expression to use for sch (e.g. "uuid.UUID"), the
+// import path that needs to be added for it (empty if none), and ok=false
+// if the default mapping should be used instead.
+type TypeMapper func(sch *jsonschema.Schema) (typ string, importPath string, ok bool)
+
+// Generator walks compiled schemas and emits Go source.
+type Generator struct {
+	// Package is the package name of the generated file.
+	Package string
+
+	// TypeMappers are consulted, in order, before the built-in mapping
+	// rules. The first one that returns ok=true wins.
+	TypeMappers []TypeMapper
+
+	named   map[*jsonschema.Schema]string
+	order   []*jsonschema.Schema
+	imports map[string]bool
+
+	// needsHasKeys is set once any unionDecl is emitted, since every
+	// discriminator function shares the same hasKeys helper.
+	needsHasKeys bool
+}
+
+// NewGenerator returns a Generator that emits into the given package.
+func NewGenerator(pkg string) *Generator {
+	return &Generator{
+		Package: pkg,
+		named:   make(map[*jsonschema.Schema]string),
+		imports: make(map[string]bool),
+	}
+}
+
+// Add registers the root schema sch to be emitted as a Go type named name.
+// Referenced schemas ($ref, definitions reached while walking sch) are
+// named from their $id/$anchor or the JSON pointer they were found at, and
+// are emitted once even if reached from multiple places.
+func (g *Generator) Add(name string, sch *jsonschema.Schema) {
+	g.name(sch, name)
+}
+
+func (g *Generator) name(sch *jsonschema.Schema, hint string) string {
+	if name, ok := g.named[sch]; ok {
+		return name
+	}
+	name := exportedName(hint)
+	g.named[sch] = name
+	g.order = append(g.order, sch)
+	return name
+}
+
+// Generate renders all added schemas (and anything they reference) as
+// formatted Go source.
+func (g *Generator) Generate() ([]byte, error) {
+	var body bytes.Buffer
+	for i := 0; i < len(g.order); i++ { // g.order grows while walking refs
+		sch := g.order[i]
+		decl, err := g.declaration(g.named[sch], sch)
+		if err != nil {
+			return nil, fmt.Errorf("codegen: %s: %w", g.named[sch], err)
+		}
+		body.WriteString(decl)
+		body.WriteString("\n\n")
+	}
+	if g.needsHasKeys {
+		body.WriteString(hasKeysHelperSrc)
+		body.WriteString("\n\n")
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "package %s\n\n", g.Package)
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for p := range g.imports {
+			imports = append(imports, p)
+		}
+		sort.Strings(imports)
+		out.WriteString("import (\n")
+		for _, p := range imports {
+			fmt.Fprintf(&out, "\t%q\n", p)
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+func (g *Generator) declaration(name string, sch *jsonschema.Schema) (string, error) {
+	if typ, imp, ok := g.mapType(sch); ok {
+		if imp != "" {
+			g.imports[imp] = true
+		}
+		return fmt.Sprintf("type %s = %s", name, typ), nil
+	}
+
+	switch {
+	case len(sch.Enum) > 0:
+		return g.enumDecl(name, sch)
+	case len(sch.AllOf) > 0:
+		return g.allOfDecl(name, sch)
+	case len(sch.OneOf) > 0:
+		return g.unionDecl(name, sch.OneOf, "OneOf")
+	case len(sch.AnyOf) > 0:
+		return g.unionDecl(name, sch.AnyOf, "AnyOf")
+	case len(sch.Properties) > 0 || sch.AdditionalProperties != nil:
+		return g.structDecl(name, sch)
+	default:
+		typ, err := g.goType(sch, name)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("type %s %s", name, typ), nil
+	}
+}
+
+// structField is one field of a generated struct, recorded so structDecl
+// can both render the struct and (when a field's type is a oneOf/anyOf
+// sealed interface) generate an UnmarshalJSON that routes that field
+// through the interface's discriminator function.
+type structField struct {
+	name     string
+	jsonKey  string
+	typ      string
+	optional bool
+	union    bool
+}
+
+func (g *Generator) structDecl(name string, sch *jsonschema.Schema) (string, error) {
+	required := make(map[string]bool, len(sch.Required))
+	for _, r := range sch.Required {
+		required[r] = true
+	}
+
+	keys := make([]string, 0, len(sch.Properties))
+	for k := range sch.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]structField, 0, len(keys))
+	for _, key := range keys {
+		prop := sch.Properties[key]
+		fieldName := exportedName(key)
+		typ, err := g.goType(prop, fieldName)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", key, err)
+		}
+		optional := !required[key]
+		if optional {
+			typ = "*" + typ
+		}
+		fields = append(fields, structField{
+			name:     fieldName,
+			jsonKey:  key,
+			typ:      typ,
+			optional: optional,
+			union:    isUnion(resolveRef(prop)),
+		})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	for _, f := range fields {
+		tag := f.jsonKey
+		if f.optional {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%q`\n", f.name, f.typ, tag)
+	}
+	b.WriteString("}")
+
+	if hasUnionField(fields) {
+		b.WriteString("\n\n")
+		b.WriteString(g.structUnmarshalDecl(name, fields))
+		g.imports["encoding/json"] = true
+	}
+	return b.String(), nil
+}
+
+func hasUnionField(fields []structField) bool {
+	for _, f := range fields {
+		if f.union {
+			return true
+		}
+	}
+	return false
+}
+
+// structUnmarshalDecl generates an UnmarshalJSON method for name that
+// decodes every plain field normally, and for each union-typed field
+// leaves it as json.RawMessage and hands it to that field's type's
+// discriminator function (unmarshal<Type>) instead of relying on
+// encoding/json's default decoding, which cannot populate an interface
+// field on its own.
+func (g *Generator) structUnmarshalDecl(name string, fields []structField) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "func (v *%s) UnmarshalJSON(data []byte) error {\n", name)
+	b.WriteString("\tvar shadow struct {\n")
+	for _, f := range fields {
+		typ := f.typ
+		if f.union {
+			typ = "json.RawMessage"
+		}
+		tag := f.jsonKey
+		if f.optional {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "\t\t%s %s `json:%q`\n", f.name, typ, tag)
+	}
+	b.WriteString("\t}\n")
+	b.WriteString("\tif err := json.Unmarshal(data, &shadow); err != nil {\n\t\treturn err\n\t}\n")
+	for _, f := range fields {
+		if !f.union {
+			fmt.Fprintf(&b, "\tv.%s = shadow.%s\n", f.name, f.name)
+			continue
+		}
+		baseType := strings.TrimPrefix(f.typ, "*")
+		fmt.Fprintf(&b, "\tif len(shadow.%s) > 0 {\n", f.name)
+		fmt.Fprintf(&b, "\t\tparsed, err := unmarshal%s(shadow.%s)\n", baseType, f.name)
+		b.WriteString("\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n")
+		if f.optional {
+			fmt.Fprintf(&b, "\t\tv.%s = &parsed\n", f.name)
+		} else {
+			fmt.Fprintf(&b, "\t\tv.%s = parsed\n", f.name)
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("\treturn nil\n}")
+	return b.String()
+}
+
+func (g *Generator) allOfDecl(name string, sch *jsonschema.Schema) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	for i, sub := range sch.AllOf {
+		embName := g.name(sub, fmt.Sprintf("%sPart%d", name, i+1))
+		fmt.Fprintf(&b, "\t%s\n", embName)
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// unionDecl renders oneOf/anyOf as a sealed interface plus the concrete
+// alternatives, along with an unmarshal<Name> discriminator function.
+// Alternatives are told apart by their own `required` properties (the one
+// piece of their schema guaranteed to distinguish them without re-running
+// full schema validation at decode time); an alternative with no required
+// properties of its own can't be discriminated this way and is skipped,
+// rather than matching every input the way a plain json.Unmarshal retry
+// would. structDecl wires unmarshal<Name> into the UnmarshalJSON of any
+// struct with a field of this type, since Name being an interface means
+// encoding/json can't populate it on its own.
+func (g *Generator) unionDecl(name string, alts []*jsonschema.Schema, suffix string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is a sealed interface: the only implementations are the %s\n", name, strings.ToLower(suffix))
+	fmt.Fprintf(&b, "// alternatives generated alongside it.\n")
+	fmt.Fprintf(&b, "type %s interface {\n\tis%s()\n}\n\n", name, name)
+
+	altNames := make([]string, len(alts))
+	for i, sub := range alts {
+		altName := g.name(sub, fmt.Sprintf("%s%s%d", name, suffix, i+1))
+		altNames[i] = altName
+		fmt.Fprintf(&b, "func (%s) is%s() {}\n", altName, name)
+	}
+
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "func unmarshal%s(data []byte) (%s, error) {\n", name, name)
+	b.WriteString("\tvar fields map[string]json.RawMessage\n")
+	b.WriteString("\tif err := json.Unmarshal(data, &fields); err != nil {\n")
+	fmt.Fprintf(&b, "\t\treturn nil, fmt.Errorf(\"%s: %%w\", err)\n", name)
+	b.WriteString("\t}\n")
+	for i, sub := range alts {
+		req := resolveRef(sub).Required
+		if len(req) == 0 {
+			continue // can't discriminate this alternative by required fields
+		}
+		fmt.Fprintf(&b, "\tif hasKeys(fields, %s) {\n", quoteStrings(req))
+		fmt.Fprintf(&b, "\t\tvar v %s\n", altNames[i])
+		b.WriteString("\t\tif err := json.Unmarshal(data, &v); err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+		b.WriteString("\t\treturn v, nil\n\t}\n")
+	}
+	fmt.Fprintf(&b, "\treturn nil, fmt.Errorf(\"%s: no alternative's required fields matched\")\n", name)
+	b.WriteString("}")
+
+	g.imports["encoding/json"] = true
+	g.imports["fmt"] = true
+	g.needsHasKeys = true
+	return b.String(), nil
+}
+
+// quoteStrings renders ss as a comma-separated list of Go string literals,
+// for splicing into a variadic call.
+func quoteStrings(ss []string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// hasKeysHelperSrc is the shared helper every unmarshal<Name> discriminator
+// calls; emitted once per generated file, only when at least one oneOf/anyOf
+// was generated.
+const hasKeysHelperSrc = `func hasKeys(fields map[string]json.RawMessage, keys ...string) bool {
+	for _, k := range keys {
+		if _, ok := fields[k]; !ok {
+			return false
+		}
+	}
+	return true
+}`
+
+func (g *Generator) enumDecl(name string, sch *jsonschema.Schema) (string, error) {
+	// primitiveType, not goType: sch is already named (it's the very enum
+	// being declared), so goType would just hand back name itself here.
+	base := primitiveType(sch)
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s %s\n\n", name, base)
+	b.WriteString("const (\n")
+	for _, v := range sch.Enum {
+		fmt.Fprintf(&b, "\t%s %s = %#v\n", name+exportedName(fmt.Sprint(v)), name, v)
+	}
+	b.WriteString(")")
+	return b.String(), nil
+}
+
+// goType returns the Go type expression for sch when it does not need its
+// own top-level declaration (scalars, arrays, and $ref to an already-named
+// type) -- or, for an inline enum/oneOf/anyOf/allOf sub-schema reached only
+// here (never passed to Add), names it and queues it onto g.order so
+// Generate still emits it via enumDecl/unionDecl/allOfDecl instead of
+// collapsing it to interface{}. hint is used to name that declaration; it
+// is not consulted for schemas that already have a name.
+func (g *Generator) goType(sch *jsonschema.Schema, hint string) (string, error) {
+	if typ, imp, ok := g.mapType(sch); ok {
+		if imp != "" {
+			g.imports[imp] = true
+		}
+		return typ, nil
+	}
+	if sch.Ref != nil {
+		return g.name(sch.Ref, refName(sch.Ref)), nil
+	}
+	if name, ok := g.named[sch]; ok {
+		return name, nil
+	}
+
+	if format, ok := formatType(sch); ok {
+		if format.importPath != "" {
+			g.imports[format.importPath] = true
+		}
+		return format.typ, nil
+	}
+
+	switch {
+	case len(sch.Enum) > 0, len(sch.AllOf) > 0, len(sch.OneOf) > 0, len(sch.AnyOf) > 0:
+		return g.name(sch, hint), nil
+	}
+
+	types := sch.Types
+	if len(types) != 1 {
+		return "interface{}", nil
+	}
+	switch types[0] {
+	case "object":
+		return "map[string]interface{}", nil
+	case "array":
+		if sch.Items != nil {
+			elem, err := g.goType(sch.Items, hint+"Item")
+			if err != nil {
+				return "", err
+			}
+			return "[]" + elem, nil
+		}
+		return "[]interface{}", nil
+	default:
+		return primitiveType(sch), nil
+	}
+}
+
+// primitiveType maps sch's JSON Schema `type` directly to a Go scalar
+// type, without consulting $ref, TypeMappers, formats, or g.named -- used
+// for the rare case (enumDecl's base type) where we need sch's own
+// primitive shape rather than whatever name it may already have.
+func primitiveType(sch *jsonschema.Schema) string {
+	if len(sch.Types) != 1 {
+		return "interface{}"
+	}
+	switch sch.Types[0] {
+	case "object":
+		return "map[string]interface{}"
+	case "array":
+		return "[]interface{}"
+	case "string":
+		return "string"
+	case "number":
+		return "float64"
+	case "integer":
+		return "int64"
+	case "boolean":
+		return "bool"
+	default:
+		return "interface{}"
+	}
+}
+
+// resolveRef follows a chain of $ref schemas (as created by
+// Compiler.compileChild) down to the first non-$ref schema.
+func resolveRef(sch *jsonschema.Schema) *jsonschema.Schema {
+	for sch.Ref != nil {
+		sch = sch.Ref
+	}
+	return sch
+}
+
+// isUnion reports whether sch (already resolved through any $ref) is
+// declared as a oneOf/anyOf sealed interface by unionDecl.
+func isUnion(sch *jsonschema.Schema) bool {
+	return len(sch.OneOf) > 0 || len(sch.AnyOf) > 0
+}
+
+func (g *Generator) mapType(sch *jsonschema.Schema) (string, string, bool) {
+	for _, m := range g.TypeMappers {
+		if typ, imp, ok := m(sch); ok {
+			return typ, imp, ok
+		}
+	}
+	return "", "", false
+}
+
+type formatMapping struct {
+	typ        string
+	importPath string
+}
+
+// formatType maps well-known `format` values to richer Go types than the
+// JSON-Schema primitive they decorate would otherwise produce.
+func formatType(sch *jsonschema.Schema) (formatMapping, bool) {
+	switch sch.Format {
+	case "date-time":
+		return formatMapping{"time.Time", "time"}, true
+	case "uuid":
+		return formatMapping{"uuid.UUID", "github.com/google/uuid"}, true
+	default:
+		return formatMapping{}, false
+	}
+}
+
+func refName(sch *jsonschema.Schema) string {
+	if sch.Location != "" {
+		parts := strings.Split(sch.Location, "/")
+		return parts[len(parts)-1]
+	}
+	return "Ref"
+}
+
+// exportedName converts a JSON Schema property/type name (snake_case,
+// kebab-case, or already camelCase) into an exported Go identifier.
+func exportedName(s string) string {
+	var b strings.Builder
+	upperNext := true
+	for _, r := range s {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			upperNext = true
+		case upperNext:
+			b.WriteRune(toUpper(r))
+			upperNext = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func toUpper(r rune) rune {
+	if r >= 'a' && r <= 'z' {
+		return r - ('a' - 'A')
+	}
+	return r
+}
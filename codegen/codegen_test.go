@@ -0,0 +1,99 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/afbjorklund/jsonschema"
+)
+
+// TestUnionDiscriminatesByRequiredFields ensures the generated
+// unmarshal<Name> function tells alternatives apart using their required
+// fields, instead of matching whichever alternative json.Unmarshal happens
+// to decode into first.
+func TestUnionDiscriminatesByRequiredFields(t *testing.T) {
+	cat := &jsonschema.Schema{Required: []string{"meow"}, Properties: map[string]*jsonschema.Schema{
+		"meow": {Types: []string{"boolean"}},
+	}}
+	dog := &jsonschema.Schema{Required: []string{"bark"}, Properties: map[string]*jsonschema.Schema{
+		"bark": {Types: []string{"boolean"}},
+	}}
+	root := &jsonschema.Schema{OneOf: []*jsonschema.Schema{cat, dog}}
+
+	g := NewGenerator("pets")
+	g.Add("Pet", root)
+	out, err := g.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	src := string(out)
+
+	if !strings.Contains(src, `hasKeys(fields, "meow")`) {
+		t.Fatalf("expected discrimination on cat's required field, got:\n%s", src)
+	}
+	if !strings.Contains(src, `hasKeys(fields, "bark")`) {
+		t.Fatalf("expected discrimination on dog's required field, got:\n%s", src)
+	}
+	if strings.Contains(src, "var errs []error") {
+		t.Fatalf("expected the old try-each-alternative unmarshaling to be gone, got:\n%s", src)
+	}
+}
+
+// TestStructWiresUnionUnmarshalJSON ensures a struct with a oneOf/anyOf
+// field gets a real UnmarshalJSON that calls the field's discriminator,
+// instead of leaving unmarshal<Name> as a dangling, never-called function.
+func TestStructWiresUnionUnmarshalJSON(t *testing.T) {
+	alt := &jsonschema.Schema{Required: []string{"x"}, Properties: map[string]*jsonschema.Schema{
+		"x": {Types: []string{"string"}},
+	}}
+	union := &jsonschema.Schema{OneOf: []*jsonschema.Schema{alt}}
+	root := &jsonschema.Schema{
+		Required:   []string{"pet"},
+		Properties: map[string]*jsonschema.Schema{"pet": union},
+	}
+
+	g := NewGenerator("pets")
+	g.Add("Owner", root)
+	out, err := g.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	src := string(out)
+
+	if !strings.Contains(src, "func (v *Owner) UnmarshalJSON(data []byte) error {") {
+		t.Fatalf("expected a generated UnmarshalJSON on Owner, got:\n%s", src)
+	}
+	if !strings.Contains(src, "unmarshalPet(shadow.Pet)") {
+		t.Fatalf("expected Owner's UnmarshalJSON to call the Pet discriminator, got:\n%s", src)
+	}
+}
+
+// TestInlineEnumIsNamedNotInterface ensures an inline enum sub-schema
+// (reached only as a struct field, never Add-ed directly) is routed
+// through enumDecl instead of collapsing to interface{}.
+func TestInlineEnumIsNamedNotInterface(t *testing.T) {
+	status := &jsonschema.Schema{Types: []string{"string"}, Enum: []interface{}{"on", "off"}}
+	root := &jsonschema.Schema{
+		Required:   []string{"status"},
+		Properties: map[string]*jsonschema.Schema{"status": status},
+	}
+
+	g := NewGenerator("pets")
+	g.Add("Light", root)
+	out, err := g.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	src := string(out)
+
+	if strings.Contains(src, "Status interface{}") {
+		t.Fatalf("expected inline enum to be named, not collapsed to interface{}, got:\n%s", src)
+	}
+	if !strings.Contains(src, "type Status string") {
+		t.Fatalf("expected a named Status enum type, got:\n%s", src)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Compiler compiles JSON Schemas into *Schema values that can validate
+// instances. The zero value is not ready to use; create one with
+// NewCompiler.
+type Compiler struct {
+	extensions map[string]extension
+
+	// formats, contentEncodings and contentMediaTypes hold the checkers
+	// registered via RegisterFormat/RegisterContentEncoding/
+	// RegisterContentMediaType. A name not present here falls back to
+	// the corresponding built-in in builtinFormats.
+	formats           map[string]FormatChecker
+	contentEncodings  map[string]FormatChecker
+	contentMediaTypes map[string]FormatChecker
+
+	// mirrors, primaryOffline and refLoader back AddSchemaLocation /
+	// SetPrimaryOffline / SetRefLoader; see mirror.go.
+	mirrors        []mirror
+	primaryOffline bool
+	refLoader      RefLoader
+	mirrorChain    *mirrorChain
+
+	// outputFormat is the OutputFormat used by Schema.ValidateOutput for
+	// schemas compiled by this Compiler; see output.go.
+	outputFormat OutputFormat
+}
+
+// NewCompiler returns an empty Compiler, ready to have schemas compiled
+// into it and extensions/formats/mirrors registered on it.
+func NewCompiler() *Compiler {
+	return &Compiler{
+		extensions: make(map[string]extension),
+		refLoader:  RefLoaderFunc(defaultLoadRef),
+	}
+}
+
+// defaultLoadRef is the RefLoader used until SetRefLoader overrides it: a
+// plain HTTP(S) GET, same as resolving a $ref has always done, except for
+// a "file" URL or a bare path (no scheme), which is read straight off
+// disk -- the usual way to compile a schema passed as a local file, such
+// as the root document given to Compile on the command line.
+func defaultLoadRef(ref string) (json.RawMessage, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	switch u.Scheme {
+	case "", "file":
+		path := ref
+		if u.Scheme == "file" {
+			path = u.Path
+		}
+		return os.ReadFile(path)
+	}
+
+	resp, err := http.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", ref, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// CompileString compiles the schema document in doc (using a fresh
+// Compiler with no extensions, formats or mirrors registered) as if it
+// had been loaded from url, without requiring it to be fetchable. This is
+// the usual way to compile a schema that is embedded in Go source, such
+// as an extension's metaschema.
+func CompileString(url string, doc string) (*Schema, error) {
+	c := NewCompiler()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &m); err != nil {
+		return nil, fmt.Errorf("jsonschema: %s: %w", url, err)
+	}
+	return c.compile(url, m)
+}
+
+// MustCompileString is like CompileString but panics on error. It is
+// meant for use in package-level variable initializers, where doc is a
+// constant and an error means the embedded schema itself is broken.
+func MustCompileString(url string, doc string) *Schema {
+	sch, err := CompileString(url, doc)
+	if err != nil {
+		panic(err)
+	}
+	return sch
+}
+
+// checkFormat looks up the checker registered (or built in) for the
+// `format` keyword value name and runs it against v. It reports ok=false
+// when name is not a known format, in which case callers must treat the
+// instance as valid -- unknown formats are never an error.
+func (c *Compiler) checkFormat(name string, v interface{}) (err error, ok bool) {
+	if check, found := c.formats[name]; found {
+		return check(v), true
+	}
+	if check, found := builtinFormats[name]; found {
+		return check(v), true
+	}
+	return nil, false
+}
+
+// checkContentEncoding and checkContentMediaType mirror checkFormat for
+// the `contentEncoding` and `contentMediaType` keywords.
+func (c *Compiler) checkContentEncoding(name string, v interface{}) (err error, ok bool) {
+	if check, found := c.contentEncodings[name]; found {
+		return check(v), true
+	}
+	if check, found := builtinContentEncodings[name]; found {
+		return check(v), true
+	}
+	return nil, false
+}
+
+func (c *Compiler) checkContentMediaType(name string, v interface{}) (err error, ok bool) {
+	if check, found := c.contentMediaTypes[name]; found {
+		return check(v), true
+	}
+	if check, found := builtinContentMediaTypes[name]; found {
+		return check(v), true
+	}
+	return nil, false
+}
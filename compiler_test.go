@@ -0,0 +1,31 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCompileLoadsLocalFile ensures Compile can load a schema straight off
+// disk, by bare path or "file" URL, the way jsonschema-gen's own
+// documented usage ("jsonschema-gen ... schema.json") expects.
+func TestCompileLoadsLocalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(`{"type":"string"}`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	c := NewCompiler()
+	if _, err := c.Compile(path); err != nil {
+		t.Fatalf("Compile(%q): %v", path, err)
+	}
+
+	c = NewCompiler()
+	if _, err := c.Compile("file://" + path); err != nil {
+		t.Fatalf("Compile(%q): %v", "file://"+path, err)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import "strings"
+
+// ValidationError is returned when an instance fails to validate against
+// a schema. Causes holds the sub-errors (e.g. one per allOf branch, or per
+// property) that led to this one, built via ValidationError.Group.
+type ValidationError struct {
+	KeywordLocation         string
+	AbsoluteKeywordLocation string
+	InstanceLocation        string
+	Message                 string
+	Causes                  []*ValidationError
+}
+
+func (e *ValidationError) Error() string {
+	return e.KeywordLocation + ": " + e.Message
+}
+
+// add attaches causes (each of which must be a *ValidationError) to e and
+// returns e, for use by extensions implementing keywords like allOf/oneOf
+// via ValidationContext's Group.
+func (e *ValidationError) add(causes ...error) *ValidationError {
+	for _, c := range causes {
+		if ve, ok := c.(*ValidationError); ok {
+			e.Causes = append(e.Causes, ve)
+		}
+	}
+	return e
+}
+
+// String renders the error and its causes as an indented tree, primarily
+// useful for debugging failing schemas.
+func (e *ValidationError) String() string {
+	var b strings.Builder
+	e.writeTo(&b, 0)
+	return b.String()
+}
+
+func (e *ValidationError) writeTo(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(e.Error())
+	b.WriteString("\n")
+	for _, c := range e.Causes {
+		c.writeTo(b, depth+1)
+	}
+}
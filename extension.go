@@ -78,7 +78,8 @@ func (ctx CompilerContext) CompileRef(ref string, refPath string, applicableOnSa
 
 // ValidationContext provides additional context required in validating for extension.
 type ValidationContext struct {
-	scope []schemaRef
+	scope  []schemaRef
+	result *annotationResult
 }
 
 // Validate validates schema s with value v. Extension must use this method instead of
@@ -101,6 +102,31 @@ func (ctx ValidationContext) Error(schemaPtr string, format string, a ...interfa
 	}
 }
 
+// Annotate records value as the annotation produced by keyword for the
+// schema currently being validated. Annotations are collected alongside
+// errors and surfaced in the OutputUnit returned for OutputFormat values
+// above Flag; extensions that implement keywords such as default-value
+// application or discriminator reporting should call this instead of (or
+// in addition to) returning an error.
+func (ctx ValidationContext) Annotate(keyword string, value interface{}) {
+	if ctx.result == nil {
+		return
+	}
+	ctx.result.annotate(ctx.scope, keyword, value)
+}
+
+// Annotations returns the annotations produced so far for keyword by
+// sibling keywords applied to the same instance location, in evaluation
+// order. This lets an extension implementing a keyword like
+// unevaluatedProperties see what "properties" or "patternProperties"
+// already matched.
+func (ctx ValidationContext) Annotations(keyword string) []interface{} {
+	if ctx.result == nil {
+		return nil
+	}
+	return ctx.result.annotations(ctx.scope, keyword)
+}
+
 // Group is used by extensions to group multiple errors as causes to parent error.
 // This is useful in implementing keywords like allOf where each schema specified
 // in allOf can result a validationError.
@@ -0,0 +1,83 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import "fmt"
+
+// FormatChecker validates the decoded JSON value v against a named format
+// (the `format` keyword), a content encoding, or a content media type.
+//
+// Unlike a plain bool-returning predicate, a FormatChecker receives the
+// already-decoded value -- a string, float64, []interface{}, etc, as
+// produced by encoding/json -- so formats are no longer restricted to
+// strings. A non-nil error explains *why* v is invalid and is surfaced
+// verbatim in ValidationError.Message.
+type FormatChecker func(v interface{}) error
+
+// builtinFormats holds the library's own format checkers, keyed by the
+// `format` keyword value they implement. A Compiler consults its own
+// c.formats first and falls back to builtinFormats, so RegisterFormat can
+// override a built-in without needing to touch this map.
+var builtinFormats = map[string]FormatChecker{}
+
+// builtinContentEncodings and builtinContentMediaTypes are the
+// contentEncoding/contentMediaType analogues of builtinFormats.
+var (
+	builtinContentEncodings  = map[string]FormatChecker{}
+	builtinContentMediaTypes = map[string]FormatChecker{}
+)
+
+// RegisterFormat registers a FormatChecker for the `format` keyword value
+// name, overriding any format (built-in or previously registered) of the
+// same name.
+func (c *Compiler) RegisterFormat(name string, check FormatChecker) {
+	if c.formats == nil {
+		c.formats = make(map[string]FormatChecker)
+	}
+	c.formats[name] = check
+}
+
+// RegisterDeprecatedFormat is a thin adapter for the old bool-returning
+// registration signature, kept for backwards compatibility. Prefer
+// RegisterFormat, whose checker can explain why a value failed.
+func (c *Compiler) RegisterDeprecatedFormat(name string, check func(v interface{}) bool) {
+	c.RegisterFormat(name, func(v interface{}) error {
+		if check(v) {
+			return nil
+		}
+		return formatError{name: name, value: v}
+	})
+}
+
+// RegisterContentEncoding registers a FormatChecker for the
+// `contentEncoding` keyword value name, overriding any encoding (built-in
+// or previously registered) of the same name. The checker always receives
+// a string, the raw instance value.
+func (c *Compiler) RegisterContentEncoding(name string, check FormatChecker) {
+	if c.contentEncodings == nil {
+		c.contentEncodings = make(map[string]FormatChecker)
+	}
+	c.contentEncodings[name] = check
+}
+
+// RegisterContentMediaType registers a FormatChecker for the
+// `contentMediaType` keyword value name, overriding any media type
+// (built-in or previously registered) of the same name. check is called
+// with the (possibly contentEncoding-decoded) string value.
+func (c *Compiler) RegisterContentMediaType(name string, check FormatChecker) {
+	if c.contentMediaTypes == nil {
+		c.contentMediaTypes = make(map[string]FormatChecker)
+	}
+	c.contentMediaTypes[name] = check
+}
+
+type formatError struct {
+	name  string
+	value interface{}
+}
+
+func (e formatError) Error() string {
+	return fmt.Sprintf("value is not a valid %s", e.name)
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import "testing"
+
+// TestValidateChecksRegisteredFormat ensures the `format` keyword actually
+// affects Schema.Validate, not just the standalone checkFormat helper.
+func TestValidateChecksRegisteredFormat(t *testing.T) {
+	c := NewCompiler()
+	c.RegisterFormat("duration", durationFormat)
+	sch, err := CompileString("https://example.com/schema.json", `{"type":"string","format":"duration"}`)
+	if err != nil {
+		t.Fatalf("CompileString: %v", err)
+	}
+	sch.up = c
+
+	if err := sch.Validate("5xy"); err == nil {
+		t.Fatalf("expected an invalid duration to fail Validate")
+	}
+	if err := sch.Validate("5h"); err != nil {
+		t.Fatalf("expected a valid duration to pass Validate, got %v", err)
+	}
+}
+
+// TestValidateChecksBuiltinFormat ensures a built-in format (not just a
+// user-registered one) is consulted during Validate.
+func TestValidateChecksBuiltinFormat(t *testing.T) {
+	sch, err := CompileString("https://example.com/schema.json", `{"type":"string","format":"email"}`)
+	if err != nil {
+		t.Fatalf("CompileString: %v", err)
+	}
+
+	if err := sch.Validate("not-an-email"); err == nil {
+		t.Fatalf("expected an invalid email to fail Validate")
+	}
+	if err := sch.Validate("user@example.com"); err != nil {
+		t.Fatalf("expected a valid email to pass Validate, got %v", err)
+	}
+}
+
+// TestValidateChecksRequiredTypeEnum ensures the core `type`/`required`/
+// `enum` keywords actually reject non-conforming instances.
+func TestValidateChecksRequiredTypeEnum(t *testing.T) {
+	sch, err := CompileString("https://example.com/schema.json", `{
+		"type": "object",
+		"required": ["name"],
+		"properties": {"name": {"type": "string"}, "status": {"enum": ["on", "off"]}}
+	}`)
+	if err != nil {
+		t.Fatalf("CompileString: %v", err)
+	}
+
+	if err := sch.Validate(map[string]interface{}{}); err == nil {
+		t.Fatalf("expected missing required property to fail Validate")
+	}
+	if err := sch.Validate(42.0); err == nil {
+		t.Fatalf("expected wrong top-level type to fail Validate")
+	}
+	if err := sch.Validate(map[string]interface{}{"name": "a", "status": "maybe"}); err == nil {
+		t.Fatalf("expected an out-of-enum value to fail Validate")
+	}
+	if err := sch.Validate(map[string]interface{}{"name": "a", "status": "on"}); err != nil {
+		t.Fatalf("expected a conforming instance to pass Validate, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// RefLoader fetches the raw schema document for ref. Implementations back
+// AddSchemaLocation mirrors: an HTTP mirror, an OCI registry, a local
+// filesystem checkout of vendored schemas, etc.
+type RefLoader interface {
+	Load(ref string) (json.RawMessage, error)
+}
+
+// RefLoaderFunc adapts a function to a RefLoader.
+type RefLoaderFunc func(ref string) (json.RawMessage, error)
+
+// Load calls f(ref).
+func (f RefLoaderFunc) Load(ref string) (json.RawMessage, error) {
+	return f(ref)
+}
+
+// AddSchemaLocation registers an additional base URL to retry a $ref
+// against when it cannot be resolved from its canonical location (network
+// error, 404, or when the canonical location has been marked offline with
+// SetPrimaryOffline). Bases are tried in the order they were added.
+//
+// For a ref like "https://example.com/schemas/foo.json#/defs/bar", adding
+// base "https://mirror.internal/upstream" retries against
+// "https://mirror.internal/upstream/schemas/foo.json#/defs/bar": the
+// canonical host+scheme is replaced, the path is kept.
+func (c *Compiler) AddSchemaLocation(baseURL string) {
+	c.mirrors = append(c.mirrors, mirror{base: strings.TrimSuffix(baseURL, "/")})
+}
+
+// SetPrimaryOffline marks the canonical ref location as unreachable so
+// resolution goes straight to the registered mirrors, without first
+// spending a network round-trip (or timeout) on the primary.
+func (c *Compiler) SetPrimaryOffline(offline bool) {
+	c.primaryOffline = offline
+}
+
+// SetRefLoader installs the RefLoader used to fetch a ref from a
+// (primary or mirror) base URL. The default loader issues a plain HTTP(S)
+// GET, same as resolving a $ref has always done.
+func (c *Compiler) SetRefLoader(l RefLoader) {
+	c.refLoader = l
+}
+
+type mirror struct {
+	base string
+}
+
+// mirrorChain resolves refs through the primary location and, on failure,
+// through each registered mirror in order. A registered mirror is a
+// single dedicated backend, so once it's observed dead, load caches that
+// against the mirror's base and skips straight past it for every
+// subsequent ref, instead of paying a fresh round-trip per ref. The
+// primary location has no such fixed identity -- refs resolved "from the
+// primary" can point at entirely unrelated hosts -- so a primary failure
+// is cached per ref instead: one missing/broken schema must not poison
+// resolution of every other, unrelated ref.
+type mirrorChain struct {
+	mu      sync.Mutex
+	failed  map[string]bool // mirror base -> true, or a failed ref itself for the primary
+	loader  RefLoader
+	offline bool
+	mirrors []mirror
+}
+
+// chain returns the Compiler's mirrorChain, creating it on first use. It
+// is cached on c (rather than rebuilt per Compile/compileRef call) so the
+// dead-base cache actually accumulates across every ref resolved during
+// the life of the Compiler.
+func (c *Compiler) chain() *mirrorChain {
+	if c.mirrorChain == nil {
+		c.mirrorChain = &mirrorChain{failed: make(map[string]bool)}
+	}
+	// Refresh the settings that AddSchemaLocation/SetPrimaryOffline/
+	// SetRefLoader may have changed since the chain was created, while
+	// keeping its accumulated per-base failure cache intact.
+	c.mirrorChain.loader = c.refLoader
+	c.mirrorChain.offline = c.primaryOffline
+	c.mirrorChain.mirrors = c.mirrors
+	return c.mirrorChain
+}
+
+// resolve loads ref, trying the primary location first (unless marked
+// offline) and then each mirror base in registration order.
+func (m *mirrorChain) resolve(ref string) (json.RawMessage, error) {
+	var errs []error
+
+	if !m.offline {
+		if data, err := m.load("", ref); err == nil {
+			return data, nil
+		} else {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, mir := range m.mirrors {
+		mref, err := rewriteBase(ref, mir.base)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if data, err := m.load(mir.base, mref); err == nil {
+			return data, nil
+		} else {
+			errs = append(errs, err)
+		}
+	}
+
+	return nil, fmt.Errorf("jsonschema: could not resolve %q from primary or %d mirror(s): %v", ref, len(m.mirrors), errs)
+}
+
+func (m *mirrorChain) load(base, ref string) (json.RawMessage, error) {
+	// The primary location ("") has no fixed identity of its own -- refs
+	// resolved against it can point at entirely unrelated hosts -- so its
+	// failures are cached per ref. A mirror base is one dedicated backend,
+	// so its failures are cached for the base as a whole.
+	key := base
+	if base == "" {
+		key = ref
+	}
+
+	m.mu.Lock()
+	if m.failed[key] {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%s: previously failed, not retrying", ref)
+	}
+	m.mu.Unlock()
+
+	data, err := m.loader.Load(ref)
+	if err != nil {
+		m.mu.Lock()
+		m.failed[key] = true
+		m.mu.Unlock()
+		return nil, err
+	}
+	return data, nil
+}
+
+// rewriteBase replaces ref's scheme and host with those of base, keeping
+// ref's path, query, and fragment.
+func rewriteBase(ref, base string) (string, error) {
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("parsing ref %q: %w", ref, err)
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("parsing mirror base %q: %w", base, err)
+	}
+	refURL.Scheme = baseURL.Scheme
+	refURL.Host = baseURL.Host
+	refURL.Path = strings.TrimSuffix(baseURL.Path, "/") + refURL.Path
+	return refURL.String(), nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// TestMirrorChainFallsBackToMirror ensures a failing primary falls through
+// to a registered mirror instead of the whole resolution failing.
+func TestMirrorChainFallsBackToMirror(t *testing.T) {
+	c := NewCompiler()
+	c.AddSchemaLocation("https://mirror.internal/upstream")
+	c.SetRefLoader(RefLoaderFunc(func(ref string) (json.RawMessage, error) {
+		if ref == "https://mirror.internal/upstream/schemas/foo.json" {
+			return json.RawMessage(`{"type":"string"}`), nil
+		}
+		return nil, fmt.Errorf("%s: not found", ref)
+	}))
+
+	chain := c.chain()
+	data, err := chain.resolve("https://example.com/schemas/foo.json")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if string(data) != `{"type":"string"}` {
+		t.Fatalf("expected mirror's document, got %s", data)
+	}
+}
+
+// TestMirrorChainCachesPrimaryFailurePerRef ensures a failing ref through
+// the primary location only caches that exact ref, so an unrelated ref
+// that the loader can serve fine is still tried rather than skipped.
+func TestMirrorChainCachesPrimaryFailurePerRef(t *testing.T) {
+	c := NewCompiler()
+	calls := 0
+	c.SetRefLoader(RefLoaderFunc(func(ref string) (json.RawMessage, error) {
+		calls++
+		if ref == "https://example.com/bad.json" {
+			return nil, fmt.Errorf("%s: not found", ref)
+		}
+		return json.RawMessage(`{"type":"string"}`), nil
+	}))
+	chain := c.chain()
+
+	if _, err := chain.resolve("https://example.com/bad.json"); err == nil {
+		t.Fatalf("expected resolve of bad.json to fail")
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 loader call after first failure, got %d", calls)
+	}
+
+	if _, err := chain.resolve("https://example.com/bad.json"); err == nil {
+		t.Fatalf("expected the cached failure to still fail bad.json without retrying")
+	}
+	if calls != 1 {
+		t.Fatalf("expected bad.json's cached failure to skip the loader on retry, got %d calls", calls)
+	}
+
+	if _, err := chain.resolve("https://example.com/good.json"); err != nil {
+		t.Fatalf("expected good.json to resolve despite bad.json's cached failure: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected good.json to reach the loader rather than be skipped, got %d calls", calls)
+	}
+}
+
+// TestMirrorChainCachesMirrorFailurePerBase ensures a dead mirror is
+// skipped for every subsequent ref routed through it, since a registered
+// mirror is a single dedicated backend rather than an arbitrary host.
+func TestMirrorChainCachesMirrorFailurePerBase(t *testing.T) {
+	c := NewCompiler()
+	c.SetPrimaryOffline(true)
+	c.AddSchemaLocation("https://mirror.internal/upstream")
+	calls := 0
+	c.SetRefLoader(RefLoaderFunc(func(ref string) (json.RawMessage, error) {
+		calls++
+		return nil, fmt.Errorf("%s: unreachable", ref)
+	}))
+	chain := c.chain()
+
+	if _, err := chain.resolve("https://example.com/a.json"); err == nil {
+		t.Fatalf("expected resolve of a.json to fail")
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 loader call after first failure, got %d", calls)
+	}
+
+	if _, err := chain.resolve("https://example.com/b.json"); err == nil {
+		t.Fatalf("expected resolve of b.json to fail")
+	}
+	if calls != 1 {
+		t.Fatalf("expected the dead mirror's cached failure to skip the loader for a different ref, got %d calls", calls)
+	}
+}
+
+// TestMirrorChainPersistsAcrossCalls ensures the Compiler reuses one
+// mirrorChain (and its accumulated failure cache) rather than building a
+// fresh one -- and forgetting everything it had learned -- on every call.
+func TestMirrorChainPersistsAcrossCalls(t *testing.T) {
+	c := NewCompiler()
+	c.SetRefLoader(RefLoaderFunc(func(ref string) (json.RawMessage, error) {
+		return nil, fmt.Errorf("%s: unreachable", ref)
+	}))
+
+	if c.chain() != c.chain() {
+		t.Fatalf("expected chain() to return the same mirrorChain across calls")
+	}
+}
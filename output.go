@@ -0,0 +1,168 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import "sync"
+
+// OutputFormat selects how much detail Validate reports, per the output
+// formats defined in draft 2019-09 section 10.
+type OutputFormat int
+
+const (
+	// OutputFlag reports only whether the instance is valid. This is the
+	// historical behavior: a nil or non-nil *ValidationError.
+	OutputFlag OutputFormat = iota
+
+	// OutputBasic reports a flat list of the errors (and, if enabled,
+	// annotations) produced during validation.
+	OutputBasic
+
+	// OutputDetailed mirrors the schema's structure: nested schemas
+	// produce nested output units, omitting units that contribute
+	// nothing (no errors, no annotations, on the successful path).
+	OutputDetailed
+
+	// OutputVerbose is like OutputDetailed but includes every unit
+	// visited during validation, successful or not.
+	OutputVerbose
+)
+
+// SetOutputFormat selects the OutputFormat used by Schema.ValidateOutput
+// for schemas compiled by this Compiler. The default is OutputFlag, i.e.
+// unchanged from Schema.Validate's plain error return.
+func (c *Compiler) SetOutputFormat(format OutputFormat) {
+	c.outputFormat = format
+}
+
+// OutputUnit is one node in the structured validation result tree
+// produced by Schema.ValidateOutput: draft 2019-09 ยง10's "basic",
+// "detailed", and "verbose" formats differ only in which units are
+// included, not in the shape of each unit.
+type OutputUnit struct {
+	Valid                   bool                   `json:"valid"`
+	KeywordLocation         string                 `json:"keywordLocation"`
+	AbsoluteKeywordLocation string                 `json:"absoluteKeywordLocation,omitempty"`
+	InstanceLocation        string                 `json:"instanceLocation"`
+	Annotations             map[string]interface{} `json:"annotations,omitempty"`
+	Errors                  []*OutputUnit          `json:"errors,omitempty"`
+}
+
+// ValidateOutput validates v the same way Validate does, but returns the
+// full OutputUnit tree (per the Compiler's configured OutputFormat)
+// instead of just the first error.
+func (s *Schema) ValidateOutput(v interface{}) (*OutputUnit, error) {
+	result := newAnnotationResult()
+	unit, err := s.validateUnit(result, nil, "", v)
+	return prune(unit, s.outputFormat()), err
+}
+
+// prune reshapes the unit tree validateUnit built down to what format
+// calls for: Flag keeps only the top-level valid/invalid verdict, Basic
+// flattens every unit (at any depth) into the root's Errors list, and
+// Detailed drops passing units that carry nothing of interest, keeping
+// the nesting Verbose always keeps in full.
+func prune(unit *OutputUnit, format OutputFormat) *OutputUnit {
+	switch format {
+	case OutputFlag:
+		return &OutputUnit{Valid: unit.Valid, KeywordLocation: unit.KeywordLocation, InstanceLocation: unit.InstanceLocation}
+	case OutputBasic:
+		root := &OutputUnit{Valid: unit.Valid, KeywordLocation: unit.KeywordLocation, AbsoluteKeywordLocation: unit.AbsoluteKeywordLocation, InstanceLocation: unit.InstanceLocation}
+		flatten(unit, &root.Errors)
+		return root
+	case OutputVerbose:
+		return unit
+	default: // OutputDetailed
+		return pruneDetailed(unit)
+	}
+}
+
+// flatten appends every invalid leaf/unit under unit (unit itself
+// included) to out, discarding the nesting -- draft 2019-09's "basic"
+// output format.
+func flatten(unit *OutputUnit, out *[]*OutputUnit) {
+	if !unit.Valid && len(unit.Errors) == 0 {
+		*out = append(*out, unit)
+	}
+	for _, child := range unit.Errors {
+		flatten(child, out)
+	}
+}
+
+// pruneDetailed mirrors the schema's structure like Verbose, but drops
+// passing child units that carry no annotations, keeping the tree small
+// when most of the schema trivially succeeded.
+func pruneDetailed(unit *OutputUnit) *OutputUnit {
+	out := &OutputUnit{
+		Valid:                   unit.Valid,
+		KeywordLocation:         unit.KeywordLocation,
+		AbsoluteKeywordLocation: unit.AbsoluteKeywordLocation,
+		InstanceLocation:        unit.InstanceLocation,
+		Annotations:             unit.Annotations,
+	}
+	for _, child := range unit.Errors {
+		if child.Valid && len(child.Errors) == 0 && len(child.Annotations) == 0 {
+			continue
+		}
+		out.Errors = append(out.Errors, pruneDetailed(child))
+	}
+	return out
+}
+
+func (s *Schema) outputFormat() OutputFormat {
+	if s.up == nil {
+		return OutputFlag
+	}
+	return s.up.outputFormat
+}
+
+// annotationResult accumulates annotations (keyed by the keyword location
+// they were produced at) over the course of one Validate/ValidateOutput
+// call, so ValidationContext.Annotate/Annotations and the final
+// OutputUnit tree can share a single source of truth.
+type annotationResult struct {
+	mu      sync.Mutex
+	entries []annotationEntry
+}
+
+type annotationEntry struct {
+	keywordLocation  string
+	instanceLocation string
+	keyword          string
+	value            interface{}
+}
+
+func newAnnotationResult() *annotationResult {
+	return &annotationResult{}
+}
+
+func (r *annotationResult) annotate(scope []schemaRef, keyword string, value interface{}) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, annotationEntry{
+		keywordLocation:  keywordLocation(scope, keyword),
+		instanceLocation: instanceLocation(scope),
+		keyword:          keyword,
+		value:            value,
+	})
+}
+
+func (r *annotationResult) annotations(scope []schemaRef, keyword string) []interface{} {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	loc := instanceLocation(scope)
+	var out []interface{}
+	for _, e := range r.entries {
+		if e.keyword == keyword && e.instanceLocation == loc {
+			out = append(out, e.value)
+		}
+	}
+	return out
+}
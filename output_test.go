@@ -0,0 +1,50 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import "testing"
+
+// TestValidateOutputReportsInvalid ensures a failing instance is reported
+// as invalid in the returned OutputUnit, not just via the error return.
+func TestValidateOutputReportsInvalid(t *testing.T) {
+	boolFalse := false
+	sch := &Schema{Location: "https://example.com/false.json", Boolean: &boolFalse}
+
+	unit, err := sch.ValidateOutput("anything")
+	if err == nil {
+		t.Fatalf("expected a validation error")
+	}
+	if unit.Valid {
+		t.Fatalf("expected unit.Valid == false for a failing instance, got true")
+	}
+}
+
+// TestValidateOutputNestsProperties ensures OutputVerbose actually mirrors
+// the schema's structure: a failing nested property produces a nested
+// OutputUnit, not just a flat annotation on the root.
+func TestValidateOutputNestsProperties(t *testing.T) {
+	boolFalse := false
+	up := &Compiler{extensions: map[string]extension{}, outputFormat: OutputVerbose}
+	child := &Schema{Location: "https://example.com/root.json#/properties/bad", Boolean: &boolFalse, up: up}
+	root := &Schema{
+		Location:   "https://example.com/root.json",
+		Properties: map[string]*Schema{"bad": child},
+		up:         up,
+	}
+
+	unit, err := root.ValidateOutput(map[string]interface{}{"bad": 1})
+	if err == nil {
+		t.Fatalf("expected a validation error")
+	}
+	if unit.Valid {
+		t.Fatalf("expected root unit.Valid == false")
+	}
+	if len(unit.Errors) != 1 {
+		t.Fatalf("expected one nested error unit for property %q, got %d", "bad", len(unit.Errors))
+	}
+	if unit.Errors[0].Valid {
+		t.Fatalf("expected nested property unit to be invalid")
+	}
+}
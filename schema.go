@@ -0,0 +1,503 @@
+// Copyright 2017 Santhosh Kumar Tekuri. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Schema is a compiled JSON Schema, ready to validate instances.
+type Schema struct {
+	Location    string
+	ID          string
+	Anchor      string
+	Title       string
+	Description string
+	Comment     string
+
+	// Boolean is non-nil when this schema is the literal `true`/`false`
+	// form allowed by the spec instead of an object.
+	Boolean *bool
+
+	// Ref is non-nil when this schema is (only) a `$ref` to another
+	// schema, already resolved at compile time.
+	Ref *Schema
+
+	Types  []string
+	Enum   []interface{}
+	Format string
+
+	// ContentEncoding and ContentMediaType are the `contentEncoding`/
+	// `contentMediaType` keyword values, checked against string
+	// instances via the Compiler's registered (or built-in) checkers;
+	// see checkContentEncoding/checkContentMediaType in compiler.go.
+	ContentEncoding  string
+	ContentMediaType string
+
+	Required             []string
+	Properties           map[string]*Schema
+	AdditionalProperties interface{}
+	Items                *Schema
+
+	AllOf []*Schema
+	AnyOf []*Schema
+	OneOf []*Schema
+
+	// ext holds the compiled ExtSchema for every extension (keyed by the
+	// name passed to RegisterExtension) that matched this schema.
+	ext map[string]ExtSchema
+
+	// up is the Compiler this schema was compiled by, consulted for
+	// compiler-wide settings such as registered formats and the
+	// configured OutputFormat.
+	up *Compiler
+}
+
+// resource is a compiled document: the root schema plus bookkeeping
+// needed to resolve `$ref`s relative to it.
+type resource struct {
+	url string
+	loc string
+	doc interface{}
+}
+
+// schemaRef is one frame of the compile/validate stack: the schema being
+// processed together with the relative-json-pointer path (in both the
+// schema document and the instance) that led to it from its parent.
+type schemaRef struct {
+	schema       *Schema
+	schemaPath   string
+	instancePath string
+}
+
+// keywordLocation renders scope (plus a final schemaPtr, e.g. the keyword
+// an error/annotation applies to) as an absolute JSON pointer into the
+// schema document, used for ValidationError.KeywordLocation and
+// OutputUnit.KeywordLocation.
+func keywordLocation(scope []schemaRef, schemaPtr string) string {
+	var b strings.Builder
+	for _, s := range scope {
+		if s.schemaPath != "" {
+			b.WriteString("/")
+			b.WriteString(s.schemaPath)
+		}
+	}
+	if schemaPtr != "" {
+		b.WriteString("/")
+		b.WriteString(schemaPtr)
+	}
+	return b.String()
+}
+
+// instanceLocation renders scope as a JSON pointer into the instance being
+// validated, used for OutputUnit.InstanceLocation and to scope
+// Annotate/Annotations to "the same instance location".
+func instanceLocation(scope []schemaRef) string {
+	var b strings.Builder
+	for _, s := range scope {
+		if s.instancePath != "" {
+			b.WriteString("/")
+			b.WriteString(s.instancePath)
+		}
+	}
+	return b.String()
+}
+
+// Compile fetches and compiles the schema identified by url (using the
+// Compiler's RefLoader, mirrors and registered extensions/formats) and
+// returns the result. Compiled schemas are not cached across calls.
+func (c *Compiler) Compile(url string) (*Schema, error) {
+	chain := c.chain()
+	data, err := chain.resolve(url)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("jsonschema: %s: %w", url, err)
+	}
+	return c.compile(url, m)
+}
+
+// compileRef resolves ref (relative to res, within the document loaded
+// for r) and compiles it, reusing stack to detect cycles the same way the
+// root Compile path does.
+func (c *Compiler) compileRef(r *resource, stack []schemaRef, schPath string, res *resource, ref string) (*Schema, error) {
+	for _, s := range stack {
+		if s.schema.Location == ref {
+			return s.schema, nil
+		}
+	}
+	chain := c.chain()
+	data, err := chain.resolve(ref)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: resolving %s: %w", ref, err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("jsonschema: %s: %w", ref, err)
+	}
+	return c.compile(ref, m)
+}
+
+func (c *Compiler) compile(location string, m map[string]interface{}) (*Schema, error) {
+	sch := &Schema{Location: location, up: c}
+	if id, ok := m["$id"].(string); ok {
+		sch.ID = id
+	}
+	if anchor, ok := m["$anchor"].(string); ok {
+		sch.Anchor = anchor
+	}
+	if title, ok := m["title"].(string); ok {
+		sch.Title = title
+	}
+	if desc, ok := m["description"].(string); ok {
+		sch.Description = desc
+	}
+	if comment, ok := m["$comment"].(string); ok {
+		sch.Comment = comment
+	}
+	if format, ok := m["format"].(string); ok {
+		sch.Format = format
+	}
+	if enc, ok := m["contentEncoding"].(string); ok {
+		sch.ContentEncoding = enc
+	}
+	if mt, ok := m["contentMediaType"].(string); ok {
+		sch.ContentMediaType = mt
+	}
+	if t, ok := m["type"].(string); ok {
+		sch.Types = []string{t}
+	} else if arr, ok := m["type"].([]interface{}); ok {
+		for _, t := range arr {
+			if s, ok := t.(string); ok {
+				sch.Types = append(sch.Types, s)
+			}
+		}
+	}
+	if enum, ok := m["enum"].([]interface{}); ok {
+		sch.Enum = enum
+	}
+	if req, ok := m["required"].([]interface{}); ok {
+		for _, r := range req {
+			if s, ok := r.(string); ok {
+				sch.Required = append(sch.Required, s)
+			}
+		}
+	}
+	if props, ok := m["properties"].(map[string]interface{}); ok {
+		sch.Properties = make(map[string]*Schema, len(props))
+		for name, v := range props {
+			sub, err := c.compileChild(location+"/properties/"+name, v)
+			if err != nil {
+				return nil, err
+			}
+			sch.Properties[name] = sub
+		}
+	}
+	if items, ok := m["items"]; ok {
+		sub, err := c.compileChild(location+"/items", items)
+		if err != nil {
+			return nil, err
+		}
+		sch.Items = sub
+	}
+	for _, kw := range []struct {
+		name string
+		dst  *[]*Schema
+	}{
+		{"allOf", &sch.AllOf},
+		{"anyOf", &sch.AnyOf},
+		{"oneOf", &sch.OneOf},
+	} {
+		arr, ok := m[kw.name].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, v := range arr {
+			sub, err := c.compileChild(fmt.Sprintf("%s/%s/%d", location, kw.name, i), v)
+			if err != nil {
+				return nil, err
+			}
+			*kw.dst = append(*kw.dst, sub)
+		}
+	}
+
+	for name, e := range c.extensions {
+		ext, err := e.compiler.Compile(CompilerContext{c: c}, m)
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: extension %s: %w", name, err)
+		}
+		if ext != nil {
+			if sch.ext == nil {
+				sch.ext = make(map[string]ExtSchema)
+			}
+			sch.ext[name] = ext
+		}
+	}
+
+	return sch, nil
+}
+
+func (c *Compiler) compileChild(location string, v interface{}) (*Schema, error) {
+	if b, ok := v.(bool); ok {
+		return &Schema{Location: location, Boolean: &b, up: c}, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("jsonschema: %s: schema must be an object or boolean", location)
+	}
+	if ref, ok := m["$ref"].(string); ok {
+		target, err := c.compileRef(nil, nil, "$ref", nil, ref)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Location: location, Ref: target, up: c}, nil
+	}
+	return c.compile(location, m)
+}
+
+// Validate checks v against s, returning nil if it is valid or a
+// *ValidationError describing the first failure otherwise. Use
+// ValidateOutput instead for the structured OutputUnit tree.
+func (s *Schema) Validate(v interface{}) error {
+	_, err := s.validateUnit(nil, nil, "", v)
+	return err
+}
+
+// validate checks v against s, pushing a new schemaRef onto scope for the
+// duration of the call. Extensions use this (via ValidationContext.Validate)
+// instead of calling Schema.validateUnit directly.
+func (s *Schema) validate(scope []schemaRef, vpath string, v interface{}) ([]interface{}, error) {
+	_, err := s.validateUnit(nil, scope, vpath, v)
+	return nil, err
+}
+
+// validateUnit is the validator loop: it walks s and the sub-schemas
+// `properties`/`items`/`allOf`/`anyOf`/`oneOf` reach, recursing into each
+// one so the OutputUnit tree Schema.ValidateOutput returns actually
+// mirrors the schema's structure instead of being a flat list of
+// extension-reported annotations. result, when non-nil, collects
+// annotations -- both the built-in `properties` annotation recorded below
+// and anything extensions report via ValidationContext.Annotate.
+//
+// The returned error is non-nil exactly when the returned unit's Valid
+// field is false; keeping both in sync is the caller's responsibility
+// (Schema.validate discards the unit, Schema.ValidateOutput discards the
+// error) since OutputUnit has no error-only equivalent of its own.
+func (s *Schema) validateUnit(result *annotationResult, scope []schemaRef, vpath string, v interface{}) (*OutputUnit, error) {
+	next := append(append([]schemaRef{}, scope...), schemaRef{schema: s, schemaPath: vpath, instancePath: vpath})
+	unit := &OutputUnit{
+		Valid:                   true,
+		KeywordLocation:         keywordLocation(next, ""),
+		AbsoluteKeywordLocation: s.Location,
+		InstanceLocation:        instanceLocation(next),
+	}
+
+	if s.Boolean != nil {
+		if !*s.Boolean {
+			err := (ValidationContext{scope: next, result: result}).Error("", "false schema always fails")
+			unit.Valid = false
+			unit.Errors = append(unit.Errors, unitFromError(unit, err))
+			return unit, err
+		}
+		return unit, nil
+	}
+
+	if s.Ref != nil {
+		child, err := s.Ref.validateUnit(result, next, "", v)
+		unit.Valid = child.Valid
+		if !child.Valid {
+			unit.Errors = append(unit.Errors, child)
+		}
+		return unit, err
+	}
+
+	ctx := ValidationContext{scope: next, result: result}
+
+	// firstErr is the error actually returned: the first keyword/extension
+	// failure encountered, location and message intact, rather than a
+	// generic "does not validate" that would throw that information away.
+	var firstErr error
+	fail := func(ve *ValidationError) {
+		unit.Valid = false
+		unit.Errors = append(unit.Errors, unitFromError(unit, ve))
+		if firstErr == nil {
+			firstErr = ve
+		}
+	}
+
+	if !checkType(s.Types, v) {
+		fail(ctx.Error("type", "value is %s, but must be %s", jsonType(v), strings.Join(s.Types, " or ")))
+	}
+	if len(s.Enum) > 0 && !enumContains(s.Enum, v) {
+		fail(ctx.Error("enum", "value must be one of %v", s.Enum))
+	}
+	if len(s.Required) > 0 {
+		if m, ok := v.(map[string]interface{}); ok {
+			for _, r := range s.Required {
+				if _, present := m[r]; !present {
+					fail(ctx.Error("required", "missing required property %q", r))
+				}
+			}
+		}
+	}
+	if str, ok := v.(string); ok {
+		decoded := str
+		if s.ContentEncoding != "" && s.up != nil {
+			if err, known := s.up.checkContentEncoding(s.ContentEncoding, str); known && err != nil {
+				fail(ctx.Error("contentEncoding", "%v", err))
+			}
+		}
+		if s.ContentMediaType != "" && s.up != nil {
+			if err, known := s.up.checkContentMediaType(s.ContentMediaType, decoded); known && err != nil {
+				fail(ctx.Error("contentMediaType", "%v", err))
+			}
+		}
+	}
+	if s.Format != "" && s.up != nil {
+		if err, known := s.up.checkFormat(s.Format, v); known && err != nil {
+			fail(ctx.Error("format", "%v", err))
+		}
+	}
+
+	for name, ext := range s.ext {
+		if err := ext.Validate(ctx, v); err != nil {
+			ve, ok := err.(*ValidationError)
+			if !ok {
+				ve = ctx.Error("", "extension %s: %v", name, err)
+			}
+			fail(ve)
+		}
+	}
+
+	if m, ok := v.(map[string]interface{}); ok {
+		for key, sub := range s.Properties {
+			val, present := m[key]
+			if !present {
+				continue
+			}
+			child, err := sub.validateUnit(result, next, "properties/"+key, val)
+			if err == nil {
+				result.annotate(next, "properties", key)
+				if unit.Annotations == nil {
+					unit.Annotations = make(map[string]interface{})
+				}
+				matched, _ := unit.Annotations["properties"].([]string)
+				unit.Annotations["properties"] = append(matched, key)
+			} else {
+				unit.Valid = false
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+			unit.Errors = append(unit.Errors, child)
+		}
+	}
+	if arr, ok := v.([]interface{}); ok && s.Items != nil {
+		for i, item := range arr {
+			child, err := s.Items.validateUnit(result, next, fmt.Sprintf("items/%d", i), item)
+			if err != nil {
+				unit.Valid = false
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+			unit.Errors = append(unit.Errors, child)
+		}
+	}
+	for _, group := range []struct {
+		keyword string
+		subs    []*Schema
+	}{
+		{"allOf", s.AllOf},
+		{"anyOf", s.AnyOf},
+		{"oneOf", s.OneOf},
+	} {
+		for _, sub := range group.subs {
+			child, err := sub.validateUnit(result, next, group.keyword, v)
+			if err != nil {
+				unit.Valid = false
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+			unit.Errors = append(unit.Errors, child)
+		}
+	}
+
+	if !unit.Valid {
+		return unit, firstErr
+	}
+	return unit, nil
+}
+
+// jsonType names v's JSON Schema primitive type, as decoded by
+// encoding/json (so JSON numbers are always "number" -- checkType handles
+// the "integer" special case separately).
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// checkType reports whether v satisfies the `type` keyword value types
+// (true when types is empty, since an untyped schema accepts anything).
+func checkType(types []string, v interface{}) bool {
+	if len(types) == 0 {
+		return true
+	}
+	actual := jsonType(v)
+	for _, t := range types {
+		if t == actual {
+			return true
+		}
+		if t == "integer" && actual == "number" {
+			if f, ok := v.(float64); ok && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enumContains reports whether v deep-equals one of enum's values, as
+// `enum` requires.
+func enumContains(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// unitFromError renders a *ValidationError, which already carries its own
+// keyword/instance locations, as a leaf OutputUnit.
+func unitFromError(parent *OutputUnit, err *ValidationError) *OutputUnit {
+	return &OutputUnit{
+		Valid:                   false,
+		KeywordLocation:         err.KeywordLocation,
+		AbsoluteKeywordLocation: err.AbsoluteKeywordLocation,
+		InstanceLocation:        parent.InstanceLocation,
+	}
+}